@@ -0,0 +1,79 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flexvolume
+
+import (
+	"fmt"
+	"math/rand"
+
+	"k8s.io/frakti/pkg/hyper/types"
+	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func init() {
+	Register(&cephRBDDriver{})
+}
+
+// CephRBDOptsData is the opts schema written by the ceph-rbd flexvolume
+// plugin.
+type CephRBDOptsData struct {
+	Pool     string   `json:"pool"`
+	VolumeID string   `json:"image"`
+	FsType   string   `json:"kubernetes.io/fsType"`
+	User     string   `json:"user"`
+	Keyring  string   `json:"keyring"`
+	Monitors []string `json:"monitors"`
+}
+
+type cephRBDDriver struct {
+	opts *CephRBDOptsData
+}
+
+func (d *cephRBDDriver) Name() string { return "cephrbd" }
+
+func (d *cephRBDDriver) Match(optsData *FlexVolumeOptsData) bool {
+	return optsData.CephRBDData != nil
+}
+
+func (d *cephRBDDriver) bindOpts(optsData *FlexVolumeOptsData) Driver {
+	return &cephRBDDriver{opts: optsData.CephRBDData}
+}
+
+func (d *cephRBDDriver) BuildVolume(volName string, m *kubeapi.Mount) (*types.UserVolumeReference, error) {
+	optsData := d.opts
+
+	volDetail := &types.UserVolume{
+		Name: volName + fmt.Sprintf("_%08x", rand.Uint32()),
+		// kuberuntime will set HostPath to the abs path of volume directory on host
+		Source: "rbd:" + optsData.Pool + "/" + optsData.VolumeID,
+		Format: "rbd",
+		Fstype: optsData.FsType,
+		Option: &types.UserVolumeOption{
+			User:     optsData.User,
+			Keyring:  optsData.Keyring,
+			Monitors: optsData.Monitors,
+		},
+	}
+
+	return &types.UserVolumeReference{
+		// use the generated volume name above
+		Volume:   volDetail.Name,
+		Path:     m.ContainerPath,
+		ReadOnly: m.Readonly,
+		Detail:   volDetail,
+	}, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flexvolume
+
+import (
+	"fmt"
+	"math/rand"
+
+	"k8s.io/frakti/pkg/hyper/types"
+	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func init() {
+	Register(&cinderDriver{})
+}
+
+// CinderVolumeOptsData is the opts schema written by the cinder flexvolume
+// plugin.
+type CinderVolumeOptsData struct {
+	VolumeType string   `json:"volume_type"`
+	Name       string   `json:"name"`
+	Hosts      []string `json:"hosts"`
+	Ports      []string `json:"ports"`
+	FsType     string   `json:"kubernetes.io/fsType"`
+}
+
+type cinderDriver struct {
+	opts *CinderVolumeOptsData
+}
+
+func (d *cinderDriver) Name() string { return "cinder" }
+
+func (d *cinderDriver) Match(optsData *FlexVolumeOptsData) bool {
+	return optsData.CinderData != nil
+}
+
+func (d *cinderDriver) bindOpts(optsData *FlexVolumeOptsData) Driver {
+	return &cinderDriver{opts: optsData.CinderData}
+}
+
+func (d *cinderDriver) BuildVolume(volName string, m *kubeapi.Mount) (*types.UserVolumeReference, error) {
+	optsData := d.opts
+
+	if optsData.VolumeType != "rbd" {
+		return nil, fmt.Errorf("got wrong volume type: %v, expected: rbd", optsData.VolumeType)
+	}
+
+	monitors := make([]string, 0, 1)
+	for _, host := range optsData.Hosts {
+		for _, port := range optsData.Ports {
+			monitors = append(monitors, fmt.Sprintf("%s:%s", host, port))
+		}
+	}
+	volDetail := &types.UserVolume{
+		Name: volName + fmt.Sprintf("_%08x", rand.Uint32()),
+		// kuberuntime will set HostPath to the abs path of volume directory on host
+		Source: "rbd:" + optsData.Name,
+		Format: optsData.VolumeType,
+		Fstype: optsData.FsType,
+	}
+	return &types.UserVolumeReference{
+		// use the generated volume name above
+		Volume:   volDetail.Name,
+		Path:     m.ContainerPath,
+		ReadOnly: m.Readonly,
+		Detail:   volDetail,
+	}, nil
+}
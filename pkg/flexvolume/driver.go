@@ -0,0 +1,80 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flexvolume
+
+import (
+	"sync"
+
+	"k8s.io/frakti/pkg/hyper/types"
+	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// Driver translates a single kind of Kubernetes flexvolume into a hyperd
+// volume reference. Implementations are stateless and registered once at
+// package init time via Register; Lookup hands back the matching Driver for
+// a decoded FlexVolumeOptsData.
+type Driver interface {
+	// Name identifies the driver, e.g. "cinder" or "iscsi".
+	Name() string
+	// Match reports whether optsData carries this driver's opts.
+	Match(optsData *FlexVolumeOptsData) bool
+	// BuildVolume builds the hyperd volume reference for m. Match stores no
+	// opts; a driver that implements bindOpts reads them from the per-call
+	// copy Lookup returns.
+	BuildVolume(volName string, m *kubeapi.Mount) (*types.UserVolumeReference, error)
+}
+
+// boundDriver is implemented by drivers that need to carry the specific
+// opts they matched on into BuildVolume. Lookup uses it, when available, to
+// hand back a per-call Driver instance rather than the shared registered
+// one, so concurrent CreateContainer calls for different volumes don't race
+// on driver state.
+type boundDriver interface {
+	bindOpts(optsData *FlexVolumeOptsData) Driver
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []Driver
+)
+
+// Register adds d to the set of known flexvolume drivers. It is expected to
+// be called from each driver's package init().
+func Register(d Driver) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, d)
+}
+
+// Lookup returns the registered Driver that claims optsData, or nil if none
+// of them do.
+func Lookup(optsData *FlexVolumeOptsData) Driver {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, d := range registry {
+		if !d.Match(optsData) {
+			continue
+		}
+		if b, ok := d.(boundDriver); ok {
+			return b.bindOpts(optsData)
+		}
+		return d
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package flexvolume recognizes volumes created by Kubernetes flexvolume
+// plugins and translates them into hyperd volume references.
+package flexvolume
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// HyperFlexvolumeDataFile is the name of the tag file a flexvolume plugin
+// drops into its mount directory to mark it as a hyper-flexvolume and to
+// carry the driver-specific options frakti needs to attach it to a VM.
+const HyperFlexvolumeDataFile = "hyperflexvolume.json"
+
+// FlexVolumeOptsData is the on-disk schema of HyperFlexvolumeDataFile. Each
+// registered Driver owns exactly one of these fields; at most one is set for
+// a given volume.
+type FlexVolumeOptsData struct {
+	CinderData  *CinderVolumeOptsData `json:"cinder,omitempty"`
+	GCEPDData   *GCEPDOptsData        `json:"gcepd,omitempty"`
+	CephRBDData *CephRBDOptsData      `json:"cephrbd,omitempty"`
+	ISCSIData   *ISCSIOptsData        `json:"iscsi,omitempty"`
+	NFSData     *NFSOptsData          `json:"nfs,omitempty"`
+}
+
+// ReadJsonOptsFile reads and decodes the HyperFlexvolumeDataFile found in
+// hostPath into optsData.
+func ReadJsonOptsFile(hostPath string, optsData *FlexVolumeOptsData) error {
+	data, err := ioutil.ReadFile(filepath.Join(hostPath, HyperFlexvolumeDataFile))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, optsData)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flexvolume
+
+import (
+	"fmt"
+	"math/rand"
+
+	"k8s.io/frakti/pkg/hyper/types"
+	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func init() {
+	Register(&gcePDDriver{})
+}
+
+// GCEPDOptsData is the opts schema written by the gce-pd flexvolume plugin.
+type GCEPDOptsData struct {
+	DevicePath string `json:"devicePath"`
+	FsType     string `json:"kubernetes.io/fsType"`
+}
+
+type gcePDDriver struct {
+	opts *GCEPDOptsData
+}
+
+func (d *gcePDDriver) Name() string { return "gcepd" }
+
+func (d *gcePDDriver) Match(optsData *FlexVolumeOptsData) bool {
+	return optsData.GCEPDData != nil
+}
+
+func (d *gcePDDriver) bindOpts(optsData *FlexVolumeOptsData) Driver {
+	return &gcePDDriver{opts: optsData.GCEPDData}
+}
+
+func (d *gcePDDriver) BuildVolume(volName string, m *kubeapi.Mount) (*types.UserVolumeReference, error) {
+	optsData := d.opts
+
+	volDetail := &types.UserVolume{
+		Name:   volName + fmt.Sprintf("_%08x", rand.Uint32()),
+		Source: optsData.DevicePath,
+		Format: "raw",
+		Fstype: optsData.FsType,
+	}
+	return &types.UserVolumeReference{
+		// use the generated volume name above
+		Volume:   volDetail.Name,
+		Path:     m.ContainerPath,
+		ReadOnly: m.Readonly,
+		Detail:   volDetail,
+	}, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flexvolume
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"k8s.io/frakti/pkg/hyper/types"
+	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+func init() {
+	Register(&iscsiDriver{})
+}
+
+// ISCSIOptsData is the opts schema written by an iscsi flexvolume plugin.
+type ISCSIOptsData struct {
+	TargetPortals []string `json:"portals"`
+	IQN           string   `json:"iqn"`
+	Lun           string   `json:"lun"`
+	FsType        string   `json:"kubernetes.io/fsType"`
+	CHAPAuth      bool     `json:"chapAuthSession"`
+	SecretName    string   `json:"secretName"`
+}
+
+type iscsiDriver struct {
+	opts *ISCSIOptsData
+}
+
+func (d *iscsiDriver) Name() string { return "iscsi" }
+
+func (d *iscsiDriver) Match(optsData *FlexVolumeOptsData) bool {
+	return optsData.ISCSIData != nil
+}
+
+func (d *iscsiDriver) bindOpts(optsData *FlexVolumeOptsData) Driver {
+	return &iscsiDriver{opts: optsData.ISCSIData}
+}
+
+func (d *iscsiDriver) BuildVolume(volName string, m *kubeapi.Mount) (*types.UserVolumeReference, error) {
+	optsData := d.opts
+
+	if len(optsData.TargetPortals) == 0 || optsData.IQN == "" {
+		return nil, fmt.Errorf("iscsi flexvolume %q is missing portals or iqn", volName)
+	}
+
+	volDetail := &types.UserVolume{
+		Name:   volName + fmt.Sprintf("_%08x", rand.Uint32()),
+		Source: fmt.Sprintf("iscsi:%s:%s:%s", strings.Join(optsData.TargetPortals, ","), optsData.IQN, optsData.Lun),
+		Format: "iscsi",
+		Fstype: optsData.FsType,
+	}
+	if optsData.CHAPAuth {
+		volDetail.Option = &types.UserVolumeOption{
+			User: optsData.SecretName,
+		}
+	}
+
+	return &types.UserVolumeReference{
+		// use the generated volume name above
+		Volume:   volDetail.Name,
+		Path:     m.ContainerPath,
+		ReadOnly: m.Readonly,
+		Detail:   volDetail,
+	}, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flexvolume
+
+import (
+	"fmt"
+	"math/rand"
+
+	"k8s.io/frakti/pkg/hyper/types"
+	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// nfsVolDriver is the "vfs" format frakti uses for plain bind-mounted
+// volumes; nfs volumes are handed to hyperd in that format, with the export
+// (server:path) as Source. This driver does not mount the export on the host.
+const nfsVolDriver = "vfs"
+
+func init() {
+	Register(&nfsDriver{})
+}
+
+// NFSOptsData is the opts schema written by an nfs flexvolume plugin.
+type NFSOptsData struct {
+	Server     string   `json:"server"`
+	Path       string   `json:"path"`
+	MountFlags []string `json:"mountOptions"`
+}
+
+type nfsDriver struct {
+	opts *NFSOptsData
+}
+
+func (d *nfsDriver) Name() string { return "nfs" }
+
+func (d *nfsDriver) Match(optsData *FlexVolumeOptsData) bool {
+	return optsData.NFSData != nil
+}
+
+func (d *nfsDriver) bindOpts(optsData *FlexVolumeOptsData) Driver {
+	return &nfsDriver{opts: optsData.NFSData}
+}
+
+func (d *nfsDriver) BuildVolume(volName string, m *kubeapi.Mount) (*types.UserVolumeReference, error) {
+	optsData := d.opts
+
+	if optsData.Server == "" || optsData.Path == "" {
+		return nil, fmt.Errorf("nfs flexvolume %q is missing server or path", volName)
+	}
+
+	// nfs shares are passed through to hyperd as a vfs bind, same as a plain
+	// host-path volume, with the export spelled out as host:path.
+	volDetail := &types.UserVolume{
+		Name:   volName + fmt.Sprintf("_%08x", rand.Uint32()),
+		Source: fmt.Sprintf("%s:%s", optsData.Server, optsData.Path),
+		Format: nfsVolDriver,
+	}
+	if len(optsData.MountFlags) > 0 {
+		volDetail.Option = &types.UserVolumeOption{MountOptions: optsData.MountFlags}
+	}
+
+	return &types.UserVolumeReference{
+		// use the generated volume name above
+		Volume:   volDetail.Name,
+		Path:     m.ContainerPath,
+		ReadOnly: m.Readonly,
+		Detail:   volDetail,
+	}, nil
+}
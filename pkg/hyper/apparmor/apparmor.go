@@ -0,0 +1,188 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package apparmor resolves Kubernetes AppArmor profile names into profiles
+// loaded into the host's AppArmor policy, for hyper-run containers.
+package apparmor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+const (
+	// ProfileRuntimeDefault is the well-known name kubelet uses to ask for
+	// the runtime's built-in default profile.
+	ProfileRuntimeDefault = "runtime/default"
+	// ProfileNameUnconfined disables AppArmor confinement entirely.
+	ProfileNameUnconfined = "unconfined"
+	// ProfileNamePrefix marks a profile that must already be loaded on the
+	// host under the given name.
+	ProfileNamePrefix = "localhost/"
+
+	// defaultProfileName is the name the synthesized runtime/default
+	// template is loaded under.
+	defaultProfileName = "frakti-default"
+)
+
+// defaultProfileTemplate is a minimal, permissive-by-default AppArmor
+// profile used whenever the pod asks for "runtime/default".
+const defaultProfileTemplate = `
+#include <tunables/global>
+
+profile ` + defaultProfileName + ` flags=(attach_disconnected,mediate_deleted) {
+  #include <abstractions/base>
+
+  file,
+  capability,
+  network,
+  mount,
+  umount,
+  ptrace,
+  signal,
+}
+`
+
+// profileState tracks whether a profile has been loaded, and guards against
+// concurrent first-uses of the same name both shelling out to
+// apparmor_parser, the same way cachedMetrics in pkg/hyper/stats.go guards a
+// single in-flight stats collection: a caller that finds a load already in
+// progress joins that load's "once" instead of starting its own.
+type profileState struct {
+	mu      sync.Mutex
+	once    *sync.Once
+	loading bool
+	loaded  bool
+	hash    string
+	err     error
+}
+
+var (
+	statesMu sync.Mutex
+	states   = make(map[string]*profileState)
+)
+
+// getOrCreateState returns the profileState for name, creating an empty one
+// if this is the first time it has been seen.
+func getOrCreateState(name string) *profileState {
+	statesMu.Lock()
+	defer statesMu.Unlock()
+
+	s, ok := states[name]
+	if !ok {
+		s = &profileState{once: &sync.Once{}}
+		states[name] = s
+	}
+	return s
+}
+
+// EnsureProfile resolves a Kubernetes AppArmor profile name to the profile
+// name hyperd should attach to the container. For "runtime/default" it loads
+// the synthesized default profile into the host's AppArmor policy via
+// apparmor_parser on first use; "localhost/<name>" profiles are not loaded.
+//
+// profileName is one of "runtime/default", "unconfined", or
+// "localhost/<name>". "unconfined" and "" both return "".
+func EnsureProfile(profileName string) (string, error) {
+	switch {
+	case profileName == "" || profileName == ProfileNameUnconfined:
+		return "", nil
+	case profileName == ProfileRuntimeDefault:
+		if err := ensureLoaded(defaultProfileName, defaultProfileTemplate); err != nil {
+			return "", err
+		}
+		return defaultProfileName, nil
+	case strings.HasPrefix(profileName, ProfileNamePrefix):
+		// Profiles referenced this way are expected to already be loaded on
+		// the host (e.g. by the node's AppArmor setup), so frakti only
+		// needs to pass the name through.
+		return strings.TrimPrefix(profileName, ProfileNamePrefix), nil
+	default:
+		return "", fmt.Errorf("invalid apparmor profile name: %q", profileName)
+	}
+}
+
+// ensureLoaded loads content under name via apparmor_parser, unless an
+// identical content has already been loaded under that name by this
+// process. Concurrent first-uses of the same name share a single
+// apparmor_parser invocation instead of each shelling out redundantly.
+func ensureLoaded(name, content string) error {
+	hash := hashContent(content)
+	state := getOrCreateState(name)
+
+	state.mu.Lock()
+	stale := !state.loaded || state.hash != hash
+	var once *sync.Once
+	if stale && !state.loading {
+		// No load is currently running: start a fresh, shared one.
+		once = &sync.Once{}
+		state.once = once
+		state.loading = true
+	} else {
+		// Either already loaded under this hash, or a load is already in
+		// flight: join it.
+		once = state.once
+	}
+	state.mu.Unlock()
+
+	once.Do(func() {
+		err := loadProfile(content)
+
+		state.mu.Lock()
+		state.loaded = err == nil
+		state.hash = hash
+		state.err = err
+		state.loading = false
+		state.mu.Unlock()
+	})
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.err
+}
+
+// loadProfile writes content to a temporary file and loads it via
+// apparmor_parser.
+func loadProfile(content string) error {
+	f, err := ioutil.TempFile("", "frakti-apparmor-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("apparmor_parser", "-Kr", f.Name())
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("apparmor_parser failed: %v: %s", err, out)
+	}
+
+	return nil
+}
+
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
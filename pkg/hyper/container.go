@@ -55,7 +55,8 @@ func (h *Runtime) CreateContainer(podSandboxID string, config *kubeapi.Container
 func buildUserContainer(config *kubeapi.ContainerConfig, sandboxConfig *kubeapi.PodSandboxConfig) (*types.UserContainer, error) {
 	privilege := false
 	readonlyRootfs := false
-	if securityContext := config.GetLinux().GetSecurityContext(); securityContext != nil {
+	securityContext := config.GetLinux().GetSecurityContext()
+	if securityContext != nil {
 		privilege = securityContext.Privileged
 		readonlyRootfs = securityContext.ReadonlyRootfs
 	}
@@ -81,6 +82,10 @@ func buildUserContainer(config *kubeapi.ContainerConfig, sandboxConfig *kubeapi.
 		ReadOnly:   readonlyRootfs,
 	}
 
+	if err := applyContainerSecurityContext(securityContext, containerSpec); err != nil {
+		return nil, err
+	}
+
 	// make volumes
 	volumes, err := makeContainerVolumes(config)
 	if err != nil {
@@ -101,75 +106,6 @@ func buildUserContainer(config *kubeapi.ContainerConfig, sandboxConfig *kubeapi.
 	return containerSpec, nil
 }
 
-func makeVolumeForCinder(optsData *flexvolume.CinderVolumeOptsData, volName string, m *kubeapi.Mount) (*types.UserVolumeReference, error) {
-	// this is a cinder-flexvolume
-	if optsData.VolumeType == "rbd" {
-		monitors := make([]string, 0, 1)
-		for _, host := range optsData.Hosts {
-			for _, port := range optsData.Ports {
-				monitors = append(monitors, fmt.Sprintf("%s:%s", host, port))
-			}
-		}
-		volDetail := &types.UserVolume{
-			Name: volName + fmt.Sprintf("_%08x", rand.Uint32()),
-			// kuberuntime will set HostPath to the abs path of volume directory on host
-			Source: "rbd:" + optsData.Name,
-			Format: optsData.VolumeType,
-			Fstype: optsData.FsType,
-		}
-		return &types.UserVolumeReference{
-			// use the generated volume name above
-			Volume:   volDetail.Name,
-			Path:     m.ContainerPath,
-			ReadOnly: m.Readonly,
-			Detail:   volDetail,
-		}, nil
-	}
-
-	return nil, fmt.Errorf("got wrong volume type: %v, expected: rbd", optsData.VolumeType)
-}
-
-func makeVolumeForGCEPD(optsData *flexvolume.GCEPDOptsData, volName string, m *kubeapi.Mount) (*types.UserVolumeReference, error) {
-	// this is a gcepd-flexvolume
-	volDetail := &types.UserVolume{
-		Name:   volName + fmt.Sprintf("_%08x", rand.Uint32()),
-		Source: optsData.DevicePath,
-		Format: "raw",
-		Fstype: optsData.FsType,
-	}
-	return &types.UserVolumeReference{
-		// use the generated volume name above
-		Volume:   volDetail.Name,
-		Path:     m.ContainerPath,
-		ReadOnly: m.Readonly,
-		Detail:   volDetail,
-	}, nil
-}
-
-func makeVolumeForCephRBD(optsData *flexvolume.CephRBDOptsData, volName string, m *kubeapi.Mount) (*types.UserVolumeReference, error) {
-	// this is a ceph-rbd flexvolume
-	volDetail := &types.UserVolume{
-		Name: volName + fmt.Sprintf("_%08x", rand.Uint32()),
-		// kuberuntime will set HostPath to the abs path of volume directory on host
-		Source: "rbd:" + optsData.Pool + "/" + optsData.VolumeID,
-		Format: "rbd",
-		Fstype: optsData.FsType,
-		Option: &types.UserVolumeOption{
-			User:     optsData.User,
-			Keyring:  optsData.Keyring,
-			Monitors: optsData.Monitors,
-		},
-	}
-
-	return &types.UserVolumeReference{
-		// use the generated volume name above
-		Volume:   volDetail.Name,
-		Path:     m.ContainerPath,
-		ReadOnly: m.Readonly,
-		Detail:   volDetail,
-	}, nil
-}
-
 func isHyperFlexVolume(hostPath, volumeOptsFile string) bool {
 	// no-exist hostPath is allowed, and that case should never be hyper flexvolume
 	if hostPathInfo, err := os.Stat(hostPath); !os.IsNotExist(err) {
@@ -195,8 +131,6 @@ func makeContainerVolumes(config *kubeapi.ContainerConfig) ([]*types.UserVolumeR
 
 		// In frakti, we can both use normal container volumes (-v host:path), and also hyper-flexvolume
 		if isHyperFlexVolume(hostPath, flexvolume.HyperFlexvolumeDataFile) {
-			var err error
-
 			optsData := flexvolume.FlexVolumeOptsData{}
 			if err := flexvolume.ReadJsonOptsFile(hostPath, &optsData); err != nil {
 				return nil, fmt.Errorf(
@@ -205,24 +139,16 @@ func makeContainerVolumes(config *kubeapi.ContainerConfig) ([]*types.UserVolumeR
 				)
 			}
 
-			switch {
-			case optsData.CinderData != nil:
-				if volumes[i], err = makeVolumeForCinder(optsData.CinderData, volName, m); err != nil {
-					return nil, err
-				}
-
-			case optsData.GCEPDData != nil:
-				if volumes[i], err = makeVolumeForGCEPD(optsData.GCEPDData, volName, m); err != nil {
-					return nil, err
-				}
-			case optsData.CephRBDData != nil:
-				if volumes[i], err = makeVolumeForCephRBD(optsData.CephRBDData, volName, m); err != nil {
-					return nil, err
-				}
-			default:
+			driver := flexvolume.Lookup(&optsData)
+			if driver == nil {
 				return nil, fmt.Errorf("hyper-flexvolume is deleted, but the driver name is unknown: %s",
 					hostPath)
 			}
+
+			var err error
+			if volumes[i], err = driver.BuildVolume(volName, m); err != nil {
+				return nil, err
+			}
 		} else {
 			// this is a normal volume
 			volDetail := &types.UserVolume{
@@ -274,6 +200,9 @@ func (h *Runtime) RemoveContainer(rawContainerID string) error {
 		return err
 	}
 
+	containerStatsCache.invalidate(rawContainerID)
+	forgetContainerResource(rawContainerID)
+
 	return nil
 }
 
@@ -436,33 +365,72 @@ func (h *Runtime) ContainerStatus(containerID string) (*kubeapi.ContainerStatus,
 	return kubeStatus, nil
 }
 
-//  UpdateContainerResources updates the resource constraints for the container.
-func (h *Runtime) UpdateContainerResources(
-	rawContainerID string,
-	config *kubeapi.LinuxContainerResources,
-) error {
-	// TODO(harry): I would suggest to run container with cpuset in docker, but we can not decide which Pod
-	// has cpuset configured. It's tricky.
-	// Also, we can not throw error here since kubelet will always execute cm.updateContainerCPUSet() by internal
-	// container life cycle hook.
-	// Will talk with @connor to see if this can be fixed.
-	return nil
-}
-
 // ContainerStats returns stats of the container. If the container does not
 // exist, the call returns an error.
 func (h *Runtime) ContainerStats(containerID string) (*kubeapi.ContainerStats, error) {
-	return nil, fmt.Errorf("ContainerStats is not implemented for hyper runtime yet.")
+	stats, err := containerStatsCache.get(containerID, func() (*kubeapi.ContainerStats, error) {
+		return h.collectContainerStats(containerID)
+	})
+	if err != nil {
+		klog.Errorf("Get container stats for %s failed: %v", containerID, err)
+		return nil, err
+	}
+
+	return stats, nil
 }
 
 // ListContainerStats returns stats of all running containers.
 func (h *Runtime) ListContainerStats(filter *kubeapi.ContainerStatsFilter) (
 	[]*kubeapi.ContainerStats, error) {
-	return nil, fmt.Errorf("ContainerStats is not implemented for hyper runtime yet.")
+	containers, err := h.listContainersMatchingStatsFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]*kubeapi.ContainerStats, 0, len(containers))
+	for _, c := range containers {
+		if c.State != kubeapi.ContainerState_CONTAINER_RUNNING {
+			continue
+		}
+		s, err := h.ContainerStats(c.Id)
+		if err != nil {
+			klog.Errorf("Get container stats for %s failed: %v", c.Id, err)
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+
+	return stats, nil
 }
 
 // ReopenContainerLog asks runtime to reopen the stdout/stderr log file for the container.
 func (h *Runtime) ReopenContainerLog(ContainerID string) error {
 	klog.V(3).Infof("ReopenContainerLog with request %s", ContainerID)
-	return fmt.Errorf("not implemented")
+
+	status, err := h.client.GetContainerInfo(ContainerID)
+	if err != nil {
+		klog.Errorf("Get container info for %s failed: %v", ContainerID, err)
+		return err
+	}
+
+	if toKubeContainerState(status.Status.Phase) != kubeapi.ContainerState_CONTAINER_RUNNING {
+		// Nothing is writing to the log right now, so there's nothing to reopen.
+		return nil
+	}
+
+	logPath := status.Container.Labels[containerLogPathLabelKey]
+	if logPath == "" {
+		return fmt.Errorf("container %s has no %s label, can't reopen its log", ContainerID, containerLogPathLabelKey)
+	}
+
+	// hyperd is the one writing the container's stdout/stderr to logPath (it
+	// has taken LogPath as part of the CreateContainer spec since before this
+	// series), so reopening it after a rotation is hyperd's job alone; frakti
+	// does not keep a second writer of its own to coordinate with.
+	if err := h.client.ReopenContainerLog(ContainerID); err != nil {
+		klog.Errorf("Hyperd reopen log for container %s failed: %v", ContainerID, err)
+		return err
+	}
+
+	return nil
 }
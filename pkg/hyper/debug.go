@@ -0,0 +1,53 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hyper
+
+import (
+	"net/http"
+
+	"k8s.io/klog"
+)
+
+// RegisterDebugHandlers wires a "generate kube YAML" inspection endpoint
+// into mux, so operators can snapshot a live pod for disaster recovery, the
+// way they might with another runtime's "generate kube" command.
+//
+// The frakti server's main must call this once, after constructing its
+// Runtime, passing http.DefaultServeMux so the endpoint is served by
+// whatever http.ListenAndServe(addr, nil) call the server already makes for
+// its other debug/metrics handlers.
+//
+// GET /debug/generatekubeyaml?podSandboxId=<id>
+func RegisterDebugHandlers(mux *http.ServeMux, h *Runtime) {
+	mux.HandleFunc("/debug/generatekubeyaml", func(w http.ResponseWriter, r *http.Request) {
+		podSandboxID := r.URL.Query().Get("podSandboxId")
+		if podSandboxID == "" {
+			http.Error(w, "missing required query parameter: podSandboxId", http.StatusBadRequest)
+			return
+		}
+
+		yamlBytes, err := h.GenerateKubeYAML(podSandboxID)
+		if err != nil {
+			klog.Errorf("GenerateKubeYAML for pod %s failed: %v", podSandboxID, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(yamlBytes)
+	})
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hyper
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/frakti/pkg/flexvolume"
+	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// GenerateKubeYAML reconstructs a v1.Pod equivalent to the workload running
+// in podSandboxID, for snapshotting a live pod (e.g. ahead of disaster
+// recovery). Since the CRI does not preserve every field of the original
+// PodSpec once a container is running, the result is a best-effort
+// approximation: image, command/args, env, workingDir, volume mounts and
+// readonly rootfs are recovered faithfully, but anything the CRI never
+// reports back (resource requests, probes, ...) is necessarily omitted.
+func (h *Runtime) GenerateKubeYAML(podSandboxID string) ([]byte, error) {
+	containers, err := h.ListContainers(&kubeapi.ContainerFilter{PodSandboxId: podSandboxID})
+	if err != nil {
+		return nil, err
+	}
+
+	pod := &v1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Pod",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: podSandboxID,
+		},
+	}
+
+	seenVolumes := make(map[string]bool)
+
+	for _, c := range containers {
+		status, err := h.ContainerStatus(c.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		info, err := h.client.GetContainerInfo(c.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		container := v1.Container{
+			Name:       status.Metadata.Name,
+			Image:      status.Image.Image,
+			Command:    info.Container.Entrypoint,
+			Args:       info.Container.Cmd,
+			WorkingDir: info.Container.WorkingDir,
+			SecurityContext: &v1.SecurityContext{
+				ReadOnlyRootFilesystem: &info.Container.ReadOnly,
+			},
+		}
+		for _, env := range info.Container.Env {
+			name, value := splitEnvVar(env)
+			container.Env = append(container.Env, v1.EnvVar{Name: name, Value: value})
+		}
+
+		for _, m := range status.Mounts {
+			volName := kubeVolumeName(m.HostPath)
+			container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{
+				Name:      volName,
+				MountPath: m.ContainerPath,
+				ReadOnly:  m.Readonly,
+			})
+
+			if seenVolumes[volName] {
+				continue
+			}
+			seenVolumes[volName] = true
+
+			vol, err := volumeFromHostPath(volName, m.HostPath)
+			if err != nil {
+				return nil, err
+			}
+			pod.Spec.Volumes = append(pod.Spec.Volumes, *vol)
+		}
+
+		pod.Spec.Containers = append(pod.Spec.Containers, container)
+	}
+
+	return yaml.Marshal(pod)
+}
+
+// volumeFromHostPath translates a mount's host path back into the
+// canonical v1.Volume a user would have declared, recognizing the same
+// hyper-flexvolume tag file isHyperFlexVolume uses.
+func volumeFromHostPath(name, hostPath string) (*v1.Volume, error) {
+	if isHyperFlexVolume(hostPath, flexvolume.HyperFlexvolumeDataFile) {
+		optsData := flexvolume.FlexVolumeOptsData{}
+		if err := flexvolume.ReadJsonOptsFile(hostPath, &optsData); err != nil {
+			return nil, fmt.Errorf("read flexvolume opts for %q: %v", hostPath, err)
+		}
+
+		switch {
+		case optsData.CinderData != nil:
+			return &v1.Volume{Name: name, VolumeSource: v1.VolumeSource{
+				Cinder: &v1.CinderVolumeSource{
+					VolumeID: optsData.CinderData.Name,
+					FSType:   optsData.CinderData.FsType,
+				},
+			}}, nil
+		case optsData.GCEPDData != nil:
+			return &v1.Volume{Name: name, VolumeSource: v1.VolumeSource{
+				GCEPersistentDisk: &v1.GCEPersistentDiskVolumeSource{
+					PDName: optsData.GCEPDData.DevicePath,
+					FSType: optsData.GCEPDData.FsType,
+				},
+			}}, nil
+		case optsData.CephRBDData != nil:
+			return &v1.Volume{Name: name, VolumeSource: v1.VolumeSource{
+				RBD: &v1.RBDVolumeSource{
+					CephMonitors: optsData.CephRBDData.Monitors,
+					RBDPool:      optsData.CephRBDData.Pool,
+					RBDImage:     optsData.CephRBDData.VolumeID,
+					FSType:       optsData.CephRBDData.FsType,
+				},
+			}}, nil
+		}
+	}
+
+	return &v1.Volume{Name: name, VolumeSource: v1.VolumeSource{
+		HostPath: &v1.HostPathVolumeSource{Path: hostPath},
+	}}, nil
+}
+
+// kubeVolumeName derives a Volume name from the last element of a mount's
+// host path, as makeContainerVolumes derives volName, except that a trailing
+// slash is ignored and an empty result becomes "volume".
+func kubeVolumeName(hostPath string) string {
+	_, name := filepath.Split(strings.TrimSuffix(hostPath, "/"))
+	if name == "" {
+		name = "volume"
+	}
+	return name
+}
+
+// splitEnvVar splits a "KEY=VALUE" environment entry as reported by hyperd.
+func splitEnvVar(env string) (name, value string) {
+	parts := strings.SplitN(env, "=", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return parts[0], ""
+}
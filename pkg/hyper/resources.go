@@ -0,0 +1,217 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hyper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/klog"
+
+	"k8s.io/frakti/pkg/hyper/types"
+	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// vmCgroupRoot is where the outer, per-pod-VM cgroup hierarchy lives on the
+// host, mirroring the path hyperd itself uses for the qemu/runv process.
+const vmCgroupRoot = "/sys/fs/cgroup"
+
+// containerResources remembers the last resource update applied to each
+// container, so the outer VM cgroup can be kept in sync with the sum of all
+// containers in a pod without having to ask hyperd for each one again.
+var containerResources = struct {
+	mu   sync.Mutex
+	byID map[string]*types.UserContainerResource
+}{byID: make(map[string]*types.UserContainerResource)}
+
+func rememberContainerResource(containerID string, resource *types.UserContainerResource) {
+	containerResources.mu.Lock()
+	defer containerResources.mu.Unlock()
+	containerResources.byID[containerID] = resource
+}
+
+// forgetContainerResource drops the remembered resource for containerID,
+// e.g. once the container has been removed, so containerResources.byID
+// doesn't grow without bound as containers churn.
+func forgetContainerResource(containerID string) {
+	containerResources.mu.Lock()
+	defer containerResources.mu.Unlock()
+	delete(containerResources.byID, containerID)
+}
+
+// ResourceUpdateNotSupportedError is returned when the connected hyperd does
+// not support live container resource updates, so callers such as kubelet
+// can detect it (via a type assertion or IsResourceUpdateNotSupported) and
+// surface a clear condition instead of treating it as a generic failure.
+type ResourceUpdateNotSupportedError struct {
+	reason string
+}
+
+func (e *ResourceUpdateNotSupportedError) Error() string {
+	return fmt.Sprintf("hyperd does not support live container resource updates: %s", e.reason)
+}
+
+// IsResourceUpdateNotSupported reports whether err is a
+// ResourceUpdateNotSupportedError.
+func IsResourceUpdateNotSupported(err error) bool {
+	_, ok := err.(*ResourceUpdateNotSupportedError)
+	return ok
+}
+
+// UpdateContainerResources updates the resource constraints for the container.
+func (h *Runtime) UpdateContainerResources(
+	rawContainerID string,
+	config *kubeapi.LinuxContainerResources,
+) error {
+	info, err := h.client.GetContainerInfo(rawContainerID)
+	if err != nil {
+		klog.Errorf("Get container info for %s failed: %v", rawContainerID, err)
+		return err
+	}
+
+	resource := buildUserContainerResource(config)
+
+	if err := h.client.UpdateContainerResource(rawContainerID, resource); err != nil {
+		if isUnsupportedResourceUpdate(err) {
+			return &ResourceUpdateNotSupportedError{reason: err.Error()}
+		}
+		klog.Errorf("Update container resource for %s failed: %v", rawContainerID, err)
+		return err
+	}
+	rememberContainerResource(rawContainerID, resource)
+
+	if resource.CPUSetCPUs != "" || resource.CPUSetMems != "" {
+		if err := h.client.SetPodCPUSet(info.PodID, resource.CPUSetCPUs, resource.CPUSetMems); err != nil {
+			klog.Errorf("Resize pinned vCPU/NUMA layout for pod %s failed: %v", info.PodID, err)
+			return err
+		}
+	}
+
+	if err := h.applyPodVMCgroup(info.PodID); err != nil {
+		klog.Errorf("Apply VM cgroup limits for pod %s failed: %v", info.PodID, err)
+		return err
+	}
+
+	return nil
+}
+
+// buildUserContainerResource translates kubeapi.LinuxContainerResources into
+// the shape hyperd expects for a container resource update.
+func buildUserContainerResource(config *kubeapi.LinuxContainerResources) *types.UserContainerResource {
+	return &types.UserContainerResource{
+		CPUShares:          config.CpuShares,
+		CPUQuota:           config.CpuQuota,
+		CPUPeriod:          config.CpuPeriod,
+		CPUSetCPUs:         config.CpusetCpus,
+		CPUSetMems:         config.CpusetMems,
+		MemoryLimitInBytes: config.MemoryLimitInBytes,
+		OOMScoreAdj:        config.OomScoreAdj,
+	}
+}
+
+// isUnsupportedResourceUpdate reports whether err indicates the connected
+// hyperd version doesn't implement live resource updates at all (as opposed
+// to e.g. an invalid value).
+func isUnsupportedResourceUpdate(err error) bool {
+	return strings.Contains(err.Error(), "not supported") || strings.Contains(err.Error(), "not implemented")
+}
+
+// applyPodVMCgroup sums the remembered resource limits of the containers in
+// podID (a container with no remembered update adds nothing) and applies
+// them to the outer VM process's own cgroup, so cAdvisor/kubelet see
+// a view of the VM that is consistent with the guest containers' settings.
+func (h *Runtime) applyPodVMCgroup(podID string) error {
+	containers, err := h.ListContainers(&kubeapi.ContainerFilter{PodSandboxId: podID})
+	if err != nil {
+		return err
+	}
+
+	containerResources.mu.Lock()
+	var totalCPUShares, totalCPUQuota, totalMemoryLimit int64
+	for _, c := range containers {
+		if resource, ok := containerResources.byID[c.Id]; ok {
+			totalCPUShares += resource.CPUShares
+			totalCPUQuota += resource.CPUQuota
+			totalMemoryLimit += resource.MemoryLimitInBytes
+		}
+	}
+	containerResources.mu.Unlock()
+
+	vmPid, err := h.client.GetPodVMPid(podID)
+	if err != nil {
+		return err
+	}
+
+	return writeVMCgroupLimits(vmPid, totalCPUShares, totalCPUQuota, totalMemoryLimit)
+}
+
+// writeVMCgroupLimits writes the cpu.shares, cpu.cfs_quota_us and
+// memory.limit_in_bytes files for the cgroup the VM process (vmPid) belongs
+// to. Zero values are left untouched since they mean "no limit configured".
+func writeVMCgroupLimits(vmPid int, cpuShares, cpuQuota, memoryLimit int64) error {
+	if cpuShares > 0 {
+		if err := writeCgroupFile(vmPid, "cpu", "cpu.shares", cpuShares); err != nil {
+			return err
+		}
+	}
+	if cpuQuota > 0 {
+		if err := writeCgroupFile(vmPid, "cpu", "cpu.cfs_quota_us", cpuQuota); err != nil {
+			return err
+		}
+	}
+	if memoryLimit > 0 {
+		if err := writeCgroupFile(vmPid, "memory", "memory.limit_in_bytes", memoryLimit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCgroupFile(vmPid int, subsystem, file string, value int64) error {
+	cgroupPath, err := vmCgroupPath(vmPid, subsystem)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(cgroupPath, file), []byte(strconv.FormatInt(value, 10)), 0644)
+}
+
+// vmCgroupPath resolves the cgroup directory a running process belongs to
+// for the given subsystem, by reading /proc/<pid>/cgroup.
+func vmCgroupPath(vmPid int, subsystem string) (string, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", vmPid))
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		for _, c := range strings.Split(fields[1], ",") {
+			if c == subsystem {
+				return filepath.Join(vmCgroupRoot, subsystem, fields[2]), nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("cgroup subsystem %q not found for pid %d", subsystem, vmPid)
+}
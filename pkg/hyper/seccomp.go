@@ -0,0 +1,86 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hyper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+const (
+	seccompProfileRuntimeDefault = "runtime/default"
+	seccompProfileUnconfined     = "unconfined"
+	seccompProfileLocalhostPfx   = "localhost/"
+)
+
+// seccompProfileRoot is where "localhost/<name>" seccomp profiles are read
+// from. It mirrors dockershim/kubelet's --seccomp-profile-root convention.
+var seccompProfileRoot = "/var/lib/kubelet/seccomp"
+
+// ociSeccompProfile is the on-disk JSON schema used for seccomp profile
+// files, matching the OCI runtime spec's Linux.Seccomp shape.
+type ociSeccompProfile struct {
+	DefaultAction specs.LinuxSeccompAction `json:"defaultAction"`
+	Architectures []specs.Arch             `json:"architectures"`
+	Syscalls      []specs.LinuxSyscall     `json:"syscalls"`
+}
+
+// loadSeccompProfile resolves a kubelet-style seccomp profile path
+// ("runtime/default", "unconfined", or "localhost/<file>") into the OCI
+// seccomp spec to attach to the container. "" and "unconfined" return nil,
+// meaning no filtering.
+func loadSeccompProfile(profilePath string) (*specs.LinuxSeccomp, error) {
+	switch {
+	case profilePath == "" || profilePath == seccompProfileUnconfined:
+		return nil, nil
+
+	case profilePath == seccompProfileRuntimeDefault:
+		// frakti has no built-in default seccomp filter of its own; rely on
+		// hyperd/runv's own default confinement inside the guest.
+		return nil, nil
+
+	case strings.HasPrefix(profilePath, seccompProfileLocalhostPfx):
+		name := strings.TrimPrefix(profilePath, seccompProfileLocalhostPfx)
+		return readSeccompProfile(filepath.Join(seccompProfileRoot, name))
+
+	default:
+		return nil, fmt.Errorf("unknown seccomp profile path: %q", profilePath)
+	}
+}
+
+func readSeccompProfile(path string) (*specs.LinuxSeccomp, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read seccomp profile %q: %v", path, err)
+	}
+
+	var profile ociSeccompProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("can't parse seccomp profile %q: %v", path, err)
+	}
+
+	return &specs.LinuxSeccomp{
+		DefaultAction: profile.DefaultAction,
+		Architectures: profile.Architectures,
+		Syscalls:      profile.Syscalls,
+	}, nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hyper
+
+import (
+	"fmt"
+	"strconv"
+
+	"k8s.io/frakti/pkg/hyper/apparmor"
+	"k8s.io/frakti/pkg/hyper/types"
+	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// applyContainerSecurityContext translates the parts of securityContext that
+// buildUserContainer doesn't already handle (Privileged, ReadonlyRootfs)
+// onto containerSpec: seccomp, AppArmor, capabilities, no-new-privileges,
+// the run-as uid and supplemental groups.
+func applyContainerSecurityContext(securityContext *kubeapi.LinuxContainerSecurityContext, containerSpec *types.UserContainer) error {
+	if securityContext == nil {
+		return nil
+	}
+
+	seccompProfile, err := loadSeccompProfile(securityContext.SeccompProfilePath)
+	if err != nil {
+		return fmt.Errorf("load seccomp profile for container %s failed: %v", containerSpec.Name, err)
+	}
+	containerSpec.SeccompProfile = seccompProfile
+
+	apparmorProfile, err := apparmor.EnsureProfile(securityContext.ApparmorProfile)
+	if err != nil {
+		return fmt.Errorf("load apparmor profile for container %s failed: %v", containerSpec.Name, err)
+	}
+	containerSpec.ApparmorProfile = apparmorProfile
+
+	if caps := securityContext.GetCapabilities(); caps != nil {
+		containerSpec.AddCapabilities = caps.AddCapabilities
+		containerSpec.DropCapabilities = caps.DropCapabilities
+	}
+
+	containerSpec.NoNewPrivileges = securityContext.NoNewPrivs
+
+	if runAsUser := securityContext.GetRunAsUser(); runAsUser != nil {
+		containerSpec.UID = strconv.FormatInt(runAsUser.Value, 10)
+	} else if securityContext.RunAsUsername != "" {
+		containerSpec.UID = securityContext.RunAsUsername
+	}
+
+	for _, gid := range securityContext.SupplementalGroups {
+		containerSpec.GroupAdd = append(containerSpec.GroupAdd, strconv.FormatInt(gid, 10))
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hyper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// defaultStatsCacheTTL is how long a collected ContainerStats entry may be
+// served from cache before a fresh collection is triggered.
+const defaultStatsCacheTTL = 10 * time.Second
+
+// hyperRootDir is where hyperd keeps per-container rootfs directories on the
+// host, used to locate the filesystem to `du`.
+const hyperRootDir = "/var/lib/hyper/vm"
+
+// cachedMetrics holds the last computed stats for a single container, along
+// with enough bookkeeping to make concurrent collection safe and cheap: a
+// single in-flight collection is shared by every caller via "once".
+type cachedMetrics struct {
+	mu sync.Mutex
+	// once guards the in-flight collection; collecting is true for exactly
+	// as long as once.Do's function hasn't returned, so a caller that finds
+	// the entry stale while a collection is already running joins that same
+	// once instead of racing to start its own.
+	once       *sync.Once
+	collecting bool
+	stats      *kubeapi.ContainerStats
+	err        error
+	timestamp  time.Time
+}
+
+// statsCache is a per-container, time-bounded cache of ContainerStats. The
+// filesystem walk used to compute disk usage is expensive, so entries are
+// only recomputed once they are older than ttl, after they have been
+// evicted (e.g. on RemoveContainer), or when the last collection failed.
+type statsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*cachedMetrics
+}
+
+func newStatsCache(ttl time.Duration) *statsCache {
+	return &statsCache{
+		ttl:     ttl,
+		entries: make(map[string]*cachedMetrics),
+	}
+}
+
+// containerStatsCache is the process-wide cache backing Runtime.ContainerStats
+// and Runtime.ListContainerStats.
+var containerStatsCache = newStatsCache(defaultStatsCacheTTL)
+
+// getOrCreate returns the cache entry for containerID, creating an empty one
+// if this is the first time the container has been seen.
+func (c *statsCache) getOrCreate(containerID string) *cachedMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[containerID]
+	if !ok {
+		entry = &cachedMetrics{once: &sync.Once{}}
+		c.entries[containerID] = entry
+	}
+	return entry
+}
+
+// invalidate drops any cached stats for containerID. It is called whenever a
+// container is removed so a future container reusing the same ID can't
+// observe stale stats.
+func (c *statsCache) invalidate(containerID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, containerID)
+}
+
+// get returns fresh stats for containerID, collecting them with collect if
+// the cached entry is missing, holds no stats (the last collection failed)
+// or is older than the cache's ttl. Concurrent
+// callers for the same container share a single collection via sync.Once.
+func (c *statsCache) get(containerID string, collect func() (*kubeapi.ContainerStats, error)) (*kubeapi.ContainerStats, error) {
+	entry := c.getOrCreate(containerID)
+
+	entry.mu.Lock()
+	stale := entry.stats == nil || time.Since(entry.timestamp) > c.ttl
+	var once *sync.Once
+	if stale && !entry.collecting {
+		// No collection is currently running: start a fresh, shared one.
+		once = &sync.Once{}
+		entry.once = once
+		entry.collecting = true
+	} else {
+		// Either still fresh, or a collection is already in flight: join it.
+		once = entry.once
+	}
+	entry.mu.Unlock()
+
+	once.Do(func() {
+		stats, err := collect()
+
+		entry.mu.Lock()
+		entry.stats = stats
+		entry.err = err
+		entry.timestamp = time.Now()
+		entry.collecting = false
+		entry.mu.Unlock()
+	})
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	return entry.stats, entry.err
+}
+
+// collectContainerStats queries the hyper client for cgroup/VM level stats
+// and walks the container's rootfs and volume mounts to compute filesystem
+// usage, then assembles a kubeapi.ContainerStats.
+func (h *Runtime) collectContainerStats(containerID string) (*kubeapi.ContainerStats, error) {
+	info, err := h.client.GetContainerInfo(containerID)
+	if err != nil {
+		klog.Errorf("Get container info for %s failed: %v", containerID, err)
+		return nil, err
+	}
+
+	podInfo, err := h.client.GetPodInfo(info.PodID)
+	if err != nil {
+		klog.Errorf("Get pod info for %s failed: %v", info.PodID, err)
+		return nil, err
+	}
+
+	cpuNanoCores, memWorkingSetBytes, err := h.client.GetContainerCgroupStats(info.PodID, containerID)
+	if err != nil {
+		klog.Errorf("Get cgroup stats for container %s failed: %v", containerID, err)
+		return nil, err
+	}
+
+	mountPaths := []string{filepath.Join(hyperRootDir, info.PodID, "containers", containerID, "rootfs")}
+	for _, m := range info.Container.VolumeMounts {
+		for _, v := range podInfo.Spec.Volumes {
+			if v.Name == m.Name && v.Source != "" {
+				mountPaths = append(mountPaths, v.Source)
+			}
+		}
+	}
+
+	fsBytes, fsInodes, err := duPaths(mountPaths)
+	if err != nil {
+		klog.Errorf("Collect filesystem usage for container %s failed: %v", containerID, err)
+		return nil, err
+	}
+
+	now := time.Now().UnixNano()
+	return &kubeapi.ContainerStats{
+		Attributes: &kubeapi.ContainerAttributes{
+			Id:     containerID,
+			Labels: getKubeletLabels(info.Container.Labels),
+		},
+		Cpu: &kubeapi.CpuUsage{
+			Timestamp:            now,
+			UsageCoreNanoSeconds: &kubeapi.UInt64Value{Value: cpuNanoCores},
+		},
+		Memory: &kubeapi.MemoryUsage{
+			Timestamp:       now,
+			WorkingSetBytes: &kubeapi.UInt64Value{Value: memWorkingSetBytes},
+		},
+		WritableLayer: &kubeapi.FilesystemUsage{
+			Timestamp:  now,
+			UsedBytes:  &kubeapi.UInt64Value{Value: fsBytes},
+			InodesUsed: &kubeapi.UInt64Value{Value: fsInodes},
+		},
+	}, nil
+}
+
+// duPaths sums up disk usage (bytes and inodes) across the container's
+// rootfs and every one of its mounted UserVolumeReferences.
+func duPaths(paths []string) (bytesUsed, inodesUsed uint64, err error) {
+	for _, p := range paths {
+		b, i, walkErr := duPath(p)
+		if walkErr != nil {
+			return 0, 0, fmt.Errorf("du %q failed: %v", p, walkErr)
+		}
+		bytesUsed += b
+		inodesUsed += i
+	}
+
+	return bytesUsed, inodesUsed, nil
+}
+
+// duPath walks root, summing the apparent sizes of all non-directory entries
+// and counting them as inodes used; directories themselves are not counted.
+// A missing path (e.g. a volume that hasn't been mounted yet) is treated as
+// zero usage rather than an error.
+func duPath(root string) (bytesUsed, inodesUsed uint64, err error) {
+	err = filepath.Walk(root, func(path string, fi os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) {
+				return nil
+			}
+			return walkErr
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		bytesUsed += uint64(fi.Size())
+		inodesUsed++
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+	return bytesUsed, inodesUsed, err
+}
+
+// listContainersMatchingStatsFilter reuses ListContainers' id/pod/label
+// parsing so ListContainerStats filters exactly like ListContainers does.
+func (h *Runtime) listContainersMatchingStatsFilter(filter *kubeapi.ContainerStatsFilter) ([]*kubeapi.Container, error) {
+	var containerFilter *kubeapi.ContainerFilter
+	if filter != nil {
+		containerFilter = &kubeapi.ContainerFilter{
+			Id:            filter.Id,
+			PodSandboxId:  filter.PodSandboxId,
+			LabelSelector: filter.LabelSelector,
+		}
+	}
+	return h.ListContainers(containerFilter)
+}
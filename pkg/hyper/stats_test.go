@@ -0,0 +1,121 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hyper
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// TestStatsCacheConcurrentGetSharesOneCollection checks that many callers
+// racing get() for the same container, while no cached entry exists yet,
+// trigger exactly one call to collect instead of one per caller.
+func TestStatsCacheConcurrentGetSharesOneCollection(t *testing.T) {
+	c := newStatsCache(time.Minute)
+
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	collect := func() (*kubeapi.ContainerStats, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+		}
+		return &kubeapi.ContainerStats{}, nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := c.get("container-1", collect); err != nil {
+				t.Errorf("get() returned error: %v", err)
+			}
+		}()
+	}
+
+	<-started
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("collect called %d times, want 1", got)
+	}
+}
+
+// TestStatsCacheRecollectsAfterTTL checks that an entry older than the
+// cache's ttl is recollected rather than served stale.
+func TestStatsCacheRecollectsAfterTTL(t *testing.T) {
+	c := newStatsCache(10 * time.Millisecond)
+
+	var calls int32
+	collect := func() (*kubeapi.ContainerStats, error) {
+		atomic.AddInt32(&calls, 1)
+		return &kubeapi.ContainerStats{}, nil
+	}
+
+	if _, err := c.get("container-1", collect); err != nil {
+		t.Fatalf("get() returned error: %v", err)
+	}
+	if _, err := c.get("container-1", collect); err != nil {
+		t.Fatalf("get() returned error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("collect called %d times before ttl expiry, want 1", got)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := c.get("container-1", collect); err != nil {
+		t.Fatalf("get() returned error: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("collect called %d times after ttl expiry, want 2", got)
+	}
+}
+
+// TestStatsCacheInvalidate checks that invalidate forces the next get() to
+// recollect rather than returning the evicted entry's stats.
+func TestStatsCacheInvalidate(t *testing.T) {
+	c := newStatsCache(time.Minute)
+
+	var calls int32
+	collect := func() (*kubeapi.ContainerStats, error) {
+		atomic.AddInt32(&calls, 1)
+		return &kubeapi.ContainerStats{}, nil
+	}
+
+	if _, err := c.get("container-1", collect); err != nil {
+		t.Fatalf("get() returned error: %v", err)
+	}
+
+	c.invalidate("container-1")
+
+	if _, err := c.get("container-1", collect); err != nil {
+		t.Fatalf("get() returned error: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("collect called %d times across invalidate, want 2", got)
+	}
+}
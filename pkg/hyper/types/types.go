@@ -0,0 +1,99 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package types mirrors the subset of hyperd's request/response schema that
+// frakti builds and reads when talking to the hyper client.
+package types
+
+import (
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// UserContainer is the spec frakti sends to hyperd to create a container
+// inside a pod sandbox VM.
+type UserContainer struct {
+	Name       string
+	Image      string
+	Workdir    string
+	Tty        bool
+	Command    []string
+	Entrypoint []string
+	Labels     map[string]string
+	LogPath    string
+	ReadOnly   bool
+	Volumes    []*UserVolumeReference
+	Envs       []*EnvironmentVar
+
+	// SeccompProfile is the OCI seccomp spec to apply to the container, or
+	// nil for an empty, "unconfined" or "runtime/default" profile path.
+	SeccompProfile *specs.LinuxSeccomp
+	// ApparmorProfile is the name of a loaded AppArmor profile to confine
+	// the container with, or empty if none was requested.
+	ApparmorProfile string
+
+	AddCapabilities  []string
+	DropCapabilities []string
+	NoNewPrivileges  bool
+	UID              string
+	GroupAdd         []string
+}
+
+// UserVolumeReference binds a UserVolume into a container at Path.
+type UserVolumeReference struct {
+	Volume   string
+	Path     string
+	ReadOnly bool
+	Detail   *UserVolume
+}
+
+// UserVolume describes a volume backing store known to hyperd.
+type UserVolume struct {
+	Name   string
+	Source string
+	Format string
+	Fstype string
+	Option *UserVolumeOption
+}
+
+// UserVolumeOption carries the extra connection details some volume formats
+// (e.g. ceph rbd) need beyond Source/Fstype.
+type UserVolumeOption struct {
+	User     string
+	Keyring  string
+	Monitors []string
+	// MountOptions are extra `-o`-style mount flags (e.g. "ro,nolock" for an
+	// nfs bind) that are not themselves a filesystem type and so must not
+	// be stuffed into Fstype.
+	MountOptions []string
+}
+
+// EnvironmentVar is a single Env/Value pair passed to a container.
+type EnvironmentVar struct {
+	Env   string
+	Value string
+}
+
+// UserContainerResource carries the cgroup-level resource constraints for a
+// single container, translated from kubeapi.LinuxContainerResources.
+type UserContainerResource struct {
+	CPUShares          int64
+	CPUQuota           int64
+	CPUPeriod          int64
+	CPUSetCPUs         string
+	CPUSetMems         string
+	MemoryLimitInBytes int64
+	OOMScoreAdj        int64
+}